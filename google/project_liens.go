@@ -0,0 +1,84 @@
+package google
+
+import (
+	"fmt"
+	"net/http"
+
+	"google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// projectLienOrigin identifies liens created by this provider so that they
+// can be distinguished from liens created by other tools sharing the same
+// project.
+const projectLienOrigin = "terraform-provider-google"
+
+// projectLienRestriction is the restriction applied to deletion-protection
+// liens; it blocks the project from being deleted while the lien exists.
+const projectLienRestriction = "resourcemanager.projects.delete"
+
+// createProjectLien creates a deletion-protection lien on the project
+// identified by pid.
+func createProjectLien(pid string, config *Config) error {
+	p, err := config.clientResourceManager.Projects.Get(pid).Do()
+	if err != nil {
+		return err
+	}
+
+	lien := &cloudresourcemanager.Lien{
+		Parent:       fmt.Sprintf("projects/%d", p.ProjectNumber),
+		Restrictions: []string{projectLienRestriction},
+		Origin:       projectLienOrigin,
+		Reason:       "Project is protected against deletion by Terraform's deletion_protection setting.",
+	}
+
+	_, err = config.clientResourceManager.Liens.Create(lien).Do()
+	return err
+}
+
+// findProjectLien returns the deletion-protection lien created by this
+// provider on p, or nil if none exists.
+func findProjectLien(p *cloudresourcemanager.Project, config *Config) (*cloudresourcemanager.Lien, error) {
+	parent := fmt.Sprintf("projects/%d", p.ProjectNumber)
+
+	res, err := config.clientResourceManager.Liens.List().Parent(parent).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	return selectManagedLien(res.Liens), nil
+}
+
+// selectManagedLien returns whichever of liens was created by this
+// provider, or nil if none of them were. Pulled out of findProjectLien so
+// the selection logic can be tested without a live Resource Manager client.
+func selectManagedLien(liens []*cloudresourcemanager.Lien) *cloudresourcemanager.Lien {
+	for _, lien := range liens {
+		if lien.Origin == projectLienOrigin {
+			return lien
+		}
+	}
+	return nil
+}
+
+// removeProjectLien deletes the lien with the given resource name (e.g.
+// "liens/1234567890").
+func removeProjectLien(lienName string, config *Config) error {
+	if lienName == "" {
+		return nil
+	}
+	_, err := config.clientResourceManager.Liens.Delete(lienName).Do()
+	return err
+}
+
+// isLienPermissionError reports whether err is a permission-denied response
+// from the Liens API, as opposed to a genuine failure. deletion_protection
+// is opt-in, so most google_project resources were never granted
+// lien-related IAM permissions in the first place.
+func isLienPermissionError(err error) bool {
+	gerr, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	return gerr.Code == http.StatusForbidden
+}
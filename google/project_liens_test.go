@@ -0,0 +1,66 @@
+package google
+
+import (
+	"net/http"
+	"testing"
+
+	"google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/googleapi"
+)
+
+func TestSelectManagedLien(t *testing.T) {
+	managed := &cloudresourcemanager.Lien{Name: "liens/managed", Origin: projectLienOrigin}
+	other := &cloudresourcemanager.Lien{Name: "liens/other", Origin: "some-other-tool"}
+
+	cases := []struct {
+		name  string
+		liens []*cloudresourcemanager.Lien
+		want  *cloudresourcemanager.Lien
+	}{
+		{"no liens", nil, nil},
+		{"only a foreign lien", []*cloudresourcemanager.Lien{other}, nil},
+		{"only the managed lien", []*cloudresourcemanager.Lien{managed}, managed},
+		{"managed lien among others", []*cloudresourcemanager.Lien{other, managed}, managed},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := selectManagedLien(c.liens)
+			if got != c.want {
+				t.Errorf("selectManagedLien() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestIsLienPermissionError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "permission denied",
+			err:  &googleapi.Error{Code: http.StatusForbidden, Message: "The caller does not have permission"},
+			want: true,
+		},
+		{
+			name: "not found is not a permission error",
+			err:  &googleapi.Error{Code: http.StatusNotFound},
+			want: false,
+		},
+		{
+			name: "non-googleapi error",
+			err:  errString("boom"),
+			want: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isLienPermissionError(c.err); got != c.want {
+				t.Errorf("isLienPermissionError(%v) = %v, want %v", c.err, got, c.want)
+			}
+		})
+	}
+}
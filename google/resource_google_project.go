@@ -3,6 +3,7 @@ package google
 import (
 	"fmt"
 	"log"
+	"math/rand"
 	"net/http"
 	"strconv"
 	"strings"
@@ -30,6 +31,11 @@ func resourceGoogleProject() *schema.Resource {
 		},
 		MigrateState: resourceGoogleProjectMigrateState,
 
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(4 * time.Minute),
+			Update: schema.DefaultTimeout(4 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"project_id": &schema.Schema{
 				Type:     schema.TypeString,
@@ -88,6 +94,37 @@ func resourceGoogleProject() *schema.Resource {
 				Elem:     &schema.Schema{Type: schema.TypeString},
 				Set:      schema.HashString,
 			},
+			"deletion_protection": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"lien_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"shared_vpc_host": &schema.Schema{
+				Type:          schema.TypeBool,
+				Optional:      true,
+				ConflictsWith: []string{"shared_vpc_host_project"},
+			},
+			"shared_vpc_host_project": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"shared_vpc_host"},
+			},
+			"parent_migration_policy": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "strict",
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					value := v.(string)
+					if value != "strict" && value != "best_effort" {
+						errors = append(errors, fmt.Errorf("%q must be either %q or %q, got: %q", k, "strict", "best_effort", value))
+					}
+					return
+				},
+			},
 		},
 	}
 }
@@ -128,12 +165,31 @@ func resourceGoogleProjectCreate(d *schema.ResourceData, meta interface{}) error
 
 	// Set the billing account
 	if _, ok := d.GetOk("billing_account"); ok {
-		err = updateProjectBillingAccount(d, config)
+		err = updateProjectBillingAccount(d, config, d.Timeout(schema.TimeoutCreate))
 		if err != nil {
 			return err
 		}
 	}
 
+	if d.Get("deletion_protection").(bool) {
+		if err = createProjectLien(pid, config); err != nil {
+			return fmt.Errorf("Error creating deletion-protection lien for project %s: %s", pid, err)
+		}
+	}
+
+	_, wantsSharedVpcHost := d.GetOk("shared_vpc_host")
+	_, wantsSharedVpcService := d.GetOk("shared_vpc_host_project")
+
+	// Both deleting the default network and configuring Shared VPC require
+	// the Compute API, so make sure it's enabled exactly once up front,
+	// before the read below ever tries to touch the Compute API, rather
+	// than duplicating the enable call in each branch below.
+	if !d.Get("auto_create_network").(bool) || wantsSharedVpcHost || wantsSharedVpcService {
+		if err = enableService("compute.googleapis.com", project.ProjectId, config); err != nil {
+			return fmt.Errorf("Error enabling the Compute Engine API required to manage networking for this project: %s", err)
+		}
+	}
+
 	err = resourceGoogleProjectRead(d, meta)
 	if err != nil {
 		return err
@@ -144,15 +200,23 @@ func resourceGoogleProjectCreate(d *schema.ResourceData, meta interface{}) error
 	// people if we don't have to.  The GCP Console is doing the same thing - creating
 	// a network and deleting it in the background.
 	if !d.Get("auto_create_network").(bool) {
-		// The compute API has to be enabled before we can delete a network.
-		if err = enableService("compute.googleapis.com", project.ProjectId, config); err != nil {
-			return fmt.Errorf("Error enabling the Compute Engine API required to delete the default network: %s", err)
-		}
-
 		if err = forceDeleteComputeNetwork(project.ProjectId, "default", config); err != nil {
 			return fmt.Errorf("Error deleting default network in project %s: %s", project.ProjectId, err)
 		}
 	}
+
+	if wantsSharedVpcHost {
+		if err = setProjectXpnHost(project.ProjectId, config); err != nil {
+			return fmt.Errorf("Error enabling Shared VPC host for project %s: %s", project.ProjectId, err)
+		}
+	}
+
+	if wantsSharedVpcService {
+		hostProject := d.Get("shared_vpc_host_project").(string)
+		if err = setProjectXpnResource(hostProject, project.ProjectId, config); err != nil {
+			return fmt.Errorf("Error attaching project %s to Shared VPC host %s: %s", project.ProjectId, hostProject, err)
+		}
+	}
 	return nil
 }
 
@@ -207,9 +271,63 @@ func resourceGoogleProjectRead(d *schema.ResourceData, meta interface{}) error {
 		}
 		d.Set("billing_account", _ba)
 	}
+
+	// Read the deletion-protection lien, if any. We report whatever we find
+	// rather than silently reconciling it with config, so that a lien
+	// created or removed out-of-band shows up as a diff instead of being
+	// clobbered on the next apply. deletion_protection is opt-in, so a
+	// permission-denied response (the common case for the many existing
+	// google_project resources that were never granted lien permissions)
+	// is tolerated rather than failing refresh for everyone.
+	lien, err := findProjectLien(p, config)
+	if err != nil {
+		if !isLienPermissionError(err) {
+			return fmt.Errorf("Error reading deletion-protection lien for project %q: %v", pid, err)
+		}
+		log.Printf("[WARN] Could not check for a deletion-protection lien on project %q, leaving deletion_protection/lien_id unchanged: %v", pid, err)
+	} else if lien != nil {
+		d.Set("deletion_protection", true)
+		d.Set("lien_id", lien.Name)
+	} else {
+		d.Set("deletion_protection", false)
+		d.Set("lien_id", "")
+	}
+
+	// Read Shared VPC state. The Compute API is not enabled by default on
+	// most projects - only the auto_create_network=false and Shared VPC
+	// code paths in Create/Update explicitly enable it - so a project that
+	// never touched either of those is expected to have Compute disabled.
+	// Treat that as "not a host, not a service project" instead of failing
+	// refresh/plan for the common case.
+	hostProject, err := config.clientCompute.Projects.GetXpnHost(pid).Do()
+	if err != nil && !isComputeApiDisabledError(err) {
+		return fmt.Errorf("Error reading Shared VPC host project for project %q: %v", pid, err)
+	}
+	if hostProject != nil && hostProject.Name != "" {
+		d.Set("shared_vpc_host_project", hostProject.Name)
+	} else {
+		d.Set("shared_vpc_host_project", "")
+	}
+
+	computeProject, err := config.clientCompute.Projects.Get(pid).Do()
+	if err != nil && !isComputeApiDisabledError(err) {
+		return fmt.Errorf("Error reading Compute project %q: %v", pid, err)
+	}
+	d.Set("shared_vpc_host", computeProject != nil && computeProject.XpnProjectStatus == "HOST")
 	return nil
 }
 
+// isComputeApiDisabledError reports whether err is the "API not enabled"
+// response Compute Engine returns for a project that hasn't activated the
+// API, as opposed to a genuine failure.
+func isComputeApiDisabledError(err error) bool {
+	gerr, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	return gerr.Code == http.StatusForbidden && strings.Contains(strings.ToLower(gerr.Message), "has not been used in project")
+}
+
 func prefixedProject(pid string) string {
 	return "projects/" + pid
 }
@@ -271,10 +389,21 @@ func resourceGoogleProjectUpdate(d *schema.ResourceData, meta interface{}) error
 
 	// Project parent has changed
 	if d.HasChange("org_id") || d.HasChange("folder_id") {
-		getParentResourceId(d, p)
+		source := p.Parent
+		tmp := &cloudresourcemanager.Project{}
+		getParentResourceId(d, tmp)
+		destination := tmp.Parent
 
-		// Do update on project
-		p, err = config.clientResourceManager.Projects.Update(p.ProjectId, p).Do()
+		if err = validateParentMigration(source, destination, config, d.Get("parent_migration_policy").(string)); err != nil {
+			return err
+		}
+
+		p.Parent = destination
+
+		// Do update on project. A move can be answered with a 409 Conflict
+		// while the API side is still settling it, so retry on that; any
+		// other error (including permission errors) is returned at once.
+		p, err = retryProjectParentMove(config, p)
 		if err != nil {
 			return fmt.Errorf("Error updating project %q: %s", project_name, err)
 		}
@@ -284,7 +413,7 @@ func resourceGoogleProjectUpdate(d *schema.ResourceData, meta interface{}) error
 
 	// Billing account has changed
 	if ok := d.HasChange("billing_account"); ok {
-		err = updateProjectBillingAccount(d, config)
+		err = updateProjectBillingAccount(d, config, d.Timeout(schema.TimeoutUpdate))
 		if err != nil {
 			return err
 		}
@@ -300,6 +429,59 @@ func resourceGoogleProjectUpdate(d *schema.ResourceData, meta interface{}) error
 			return fmt.Errorf("Error updating project %q: %s", project_name, err)
 		}
 	}
+
+	// Deletion protection has changed
+	if ok := d.HasChange("deletion_protection"); ok {
+		if d.Get("deletion_protection").(bool) {
+			if err = createProjectLien(pid, config); err != nil {
+				return fmt.Errorf("Error creating deletion-protection lien for project %s: %s", pid, err)
+			}
+		} else {
+			if err = removeProjectLien(d.Get("lien_id").(string), config); err != nil {
+				return fmt.Errorf("Error removing deletion-protection lien for project %s: %s", pid, err)
+			}
+		}
+		d.SetPartial("deletion_protection")
+	}
+
+	// Shared VPC host status or service project attachment has changed.
+	// Both require the Compute API, which - unlike at Create time - we
+	// can't assume is already enabled, so guard it here the same way.
+	if d.HasChange("shared_vpc_host") || d.HasChange("shared_vpc_host_project") {
+		if err = enableService("compute.googleapis.com", pid, config); err != nil {
+			return fmt.Errorf("Error enabling the Compute Engine API required to manage Shared VPC for this project: %s", err)
+		}
+	}
+
+	// Shared VPC host status has changed
+	if ok := d.HasChange("shared_vpc_host"); ok {
+		if d.Get("shared_vpc_host").(bool) {
+			if err = setProjectXpnHost(pid, config); err != nil {
+				return fmt.Errorf("Error enabling Shared VPC host for project %s: %s", pid, err)
+			}
+		} else {
+			if err = clearProjectXpnHost(pid, config); err != nil {
+				return fmt.Errorf("Error disabling Shared VPC host for project %s: %s", pid, err)
+			}
+		}
+		d.SetPartial("shared_vpc_host")
+	}
+
+	// Shared VPC service project attachment has changed
+	if ok := d.HasChange("shared_vpc_host_project"); ok {
+		old, new := d.GetChange("shared_vpc_host_project")
+		if old.(string) != "" {
+			if err = clearProjectXpnResource(old.(string), pid, config); err != nil {
+				return fmt.Errorf("Error detaching project %s from Shared VPC host %s: %s", pid, old.(string), err)
+			}
+		}
+		if new.(string) != "" {
+			if err = setProjectXpnResource(new.(string), pid, config); err != nil {
+				return fmt.Errorf("Error attaching project %s to Shared VPC host %s: %s", pid, new.(string), err)
+			}
+		}
+		d.SetPartial("shared_vpc_host_project")
+	}
 	d.Partial(false)
 
 	return nil
@@ -310,6 +492,15 @@ func resourceGoogleProjectDelete(d *schema.ResourceData, meta interface{}) error
 	// Only delete projects if skip_delete isn't set
 	if !d.Get("skip_delete").(bool) {
 		pid := d.Id()
+
+		// The lien has to be removed before Resource Manager will allow the
+		// project to be deleted.
+		if lienId := d.Get("lien_id").(string); lienId != "" {
+			if err := removeProjectLien(lienId, config); err != nil {
+				return fmt.Errorf("Error removing deletion-protection lien for project %q: %s", pid, err)
+			}
+		}
+
 		_, err := config.clientResourceManager.Projects.Delete(pid).Do()
 		if err != nil {
 			return fmt.Errorf("Error deleting project %q: %s", pid, err)
@@ -358,7 +549,7 @@ func forceDeleteComputeNetwork(projectId, networkName string, config *Config) er
 	return deleteComputeNetwork(projectId, networkName, config)
 }
 
-func updateProjectBillingAccount(d *schema.ResourceData, config *Config) error {
+func updateProjectBillingAccount(d *schema.ResourceData, config *Config, timeout time.Duration) error {
 	pid := d.Id()
 	name := d.Get("billing_account").(string)
 	ba := cloudbilling.ProjectBillingInfo{}
@@ -374,19 +565,62 @@ func updateProjectBillingAccount(d *schema.ResourceData, config *Config) error {
 		}
 		return fmt.Errorf("Error setting billing account %q for project %q: %v", name, prefixedProject(pid), err)
 	}
-	for retries := 0; retries < 3; retries++ {
-		err = resourceGoogleProjectRead(d, config)
+
+	return waitForBillingAccountPropagation(config, pid, name, timeout)
+}
+
+// waitForBillingAccountPropagation polls the project's billing info directly
+// (rather than going through resourceGoogleProjectRead, which would also
+// overwrite unrelated fields like labels and parent) until it reflects the
+// billing account we just set, using exponential backoff with jitter. Errors
+// that indicate the billing account hasn't propagated to Resource Manager
+// yet are retried; anything else - most commonly a genuine permission
+// problem - is returned immediately.
+func waitForBillingAccountPropagation(config *Config, pid, want string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	backoff := 2 * time.Second
+	const maxBackoff = 30 * time.Second
+
+	for {
+		ba, err := config.clientBilling.Projects.GetBillingInfo(prefixedProject(pid)).Do()
 		if err != nil {
-			return err
+			if !isBillingPropagationError(err) {
+				return fmt.Errorf("Error reading billing account for project %q: %v", prefixedProject(pid), err)
+			}
+		} else if strings.TrimPrefix(ba.BillingAccountName, "billingAccounts/") == want {
+			return nil
+		}
+
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("Timed out waiting for billing account %q to propagate for project %q.", want, pid)
 		}
-		if d.Get("billing_account").(string) == name {
-			break
+
+		log.Printf("[DEBUG] Billing account for project %q not yet propagated, retrying in %s", pid, backoff)
+		time.Sleep(backoff + jitter(backoff))
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
 		}
-		time.Sleep(3)
 	}
-	if d.Get("billing_account").(string) != name {
-		return fmt.Errorf("Timed out waiting for billing account to return correct value.  Waiting for %s, got %s.",
-			d.Get("billding_account").(string), name)
+}
+
+// isBillingPropagationError reports whether err looks like a transient
+// "billing account not yet visible" response rather than a genuine
+// permission failure.
+func isBillingPropagationError(err error) bool {
+	gerr, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
 	}
-	return nil
+	if gerr.Code != http.StatusConflict && gerr.Code != http.StatusForbidden {
+		return false
+	}
+	return strings.Contains(strings.ToLower(gerr.Message), "not yet") ||
+		strings.Contains(strings.ToLower(gerr.Message), "propagat")
+}
+
+// jitter returns a random duration in [0, d/2) to avoid retry stampedes.
+func jitter(d time.Duration) time.Duration {
+	return time.Duration(rand.Int63n(int64(d) / 2))
 }
@@ -0,0 +1,56 @@
+package google
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestIsBillingPropagationError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "conflict not yet propagated",
+			err:  &googleapi.Error{Code: http.StatusConflict, Message: "Billing account not yet propagated to project"},
+			want: true,
+		},
+		{
+			name: "forbidden still propagating",
+			err:  &googleapi.Error{Code: http.StatusForbidden, Message: "Billing account is still propagating"},
+			want: true,
+		},
+		{
+			name: "genuine permission error",
+			err:  &googleapi.Error{Code: http.StatusForbidden, Message: "The caller does not have permission to set billing account"},
+			want: false,
+		},
+		{
+			name: "non-googleapi error",
+			err:  errString("boom"),
+			want: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isBillingPropagationError(c.err); got != c.want {
+				t.Errorf("isBillingPropagationError(%v) = %v, want %v", c.err, got, c.want)
+			}
+		})
+	}
+}
+
+func TestJitter(t *testing.T) {
+	d := 8 * time.Second
+	for i := 0; i < 100; i++ {
+		j := jitter(d)
+		if j < 0 || j >= d/2 {
+			t.Fatalf("jitter(%s) = %s, want in [0, %s)", d, j, d/2)
+		}
+	}
+}
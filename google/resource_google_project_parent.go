@@ -0,0 +1,121 @@
+package google
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// validateParentMigration verifies that the caller holds the permissions
+// Resource Manager requires to move a project from source to destination
+// before we ever call Projects.Update. A move that's rejected mid-flight
+// can leave a project with neither its old nor its new parent, so we'd
+// rather fail before making any change than risk that.
+func validateParentMigration(source, destination *cloudresourcemanager.ResourceId, config *Config, policy string) error {
+	// Checking itself can fail (e.g. the caller can't even probe IAM on a
+	// folder it hasn't been granted access to yet) as easily as it can
+	// succeed and report a gap. Either way we can't confirm the move will
+	// succeed, so both outcomes feed the same missing-permissions path
+	// rather than the check error short-circuiting past it with a raw
+	// googleapi error.
+	var missing []string
+
+	if source != nil {
+		ok, err := testParentIamPermission(source, "resourcemanager.projects.move", config)
+		if err != nil {
+			missing = append(missing, fmt.Sprintf("resourcemanager.projects.move on %s %s (could not verify: %s)", source.Type, source.Id, err))
+		} else if !ok {
+			missing = append(missing, fmt.Sprintf("resourcemanager.projects.move on %s %s", source.Type, source.Id))
+		}
+	}
+
+	if destination != nil {
+		ok, err := testParentIamPermission(destination, "resourcemanager.projects.create", config)
+		if err != nil {
+			missing = append(missing, fmt.Sprintf("resourcemanager.projects.create on %s %s (could not verify: %s)", destination.Type, destination.Id, err))
+		} else if !ok {
+			missing = append(missing, fmt.Sprintf("resourcemanager.projects.create on %s %s", destination.Type, destination.Id))
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if policy == "best_effort" {
+		log.Printf("[WARN] Proceeding with project parent migration despite missing permissions (parent_migration_policy = best_effort): %v", missing)
+		return nil
+	}
+
+	return fmt.Errorf("Insufficient permissions to move project parent, missing: %s. "+
+		"Set parent_migration_policy = \"best_effort\" to attempt the move anyway.", strings.Join(missing, "; "))
+}
+
+// testParentIamPermission reports whether the caller holds permission on
+// the given organization or folder resource.
+func testParentIamPermission(parent *cloudresourcemanager.ResourceId, permission string, config *Config) (bool, error) {
+	req := &cloudresourcemanager.TestIamPermissionsRequest{
+		Permissions: []string{permission},
+	}
+
+	switch parent.Type {
+	case "organization":
+		res, err := config.clientResourceManager.Organizations.TestIamPermissions(fmt.Sprintf("organizations/%s", parent.Id), req).Do()
+		if err != nil {
+			return false, err
+		}
+		return len(res.Permissions) > 0, nil
+	case "folder":
+		res, err := config.clientResourceManagerV2Beta1.Folders.TestIamPermissions(fmt.Sprintf("folders/%s", parent.Id), req).Do()
+		if err != nil {
+			return false, err
+		}
+		return len(res.Permissions) > 0, nil
+	default:
+		return false, fmt.Errorf("Unknown parent resource type %q", parent.Type)
+	}
+}
+
+// retryProjectParentMove retries Projects.Update for a parent change,
+// backing off between attempts. Resource Manager treats a parent move as
+// an asynchronous operation under the hood; transient errors while it's in
+// flight are retried rather than surfaced as a permanent failure.
+func retryProjectParentMove(config *Config, p *cloudresourcemanager.Project) (*cloudresourcemanager.Project, error) {
+	var result *cloudresourcemanager.Project
+	var err error
+
+	backoff := 2 * time.Second
+	const maxAttempts = 5
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		result, err = config.clientResourceManager.Projects.Update(p.ProjectId, p).Do()
+		if err == nil {
+			return result, nil
+		}
+		if !isMovePendingError(err) {
+			return nil, err
+		}
+
+		log.Printf("[DEBUG] Project parent move for %q not yet accepted, retrying in %s", p.ProjectId, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	return nil, err
+}
+
+// isMovePendingError reports whether err looks like the asynchronous move
+// operation is still settling on the API side, as opposed to a permanent
+// failure.
+func isMovePendingError(err error) bool {
+	gerr, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	return gerr.Code == http.StatusConflict
+}
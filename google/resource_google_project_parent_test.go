@@ -0,0 +1,40 @@
+package google
+
+import (
+	"net/http"
+	"testing"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestIsMovePendingError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "conflict",
+			err:  &googleapi.Error{Code: http.StatusConflict},
+			want: true,
+		},
+		{
+			name: "forbidden is not retried",
+			err:  &googleapi.Error{Code: http.StatusForbidden},
+			want: false,
+		},
+		{
+			name: "non-googleapi error",
+			err:  errString("boom"),
+			want: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isMovePendingError(c.err); got != c.want {
+				t.Errorf("isMovePendingError(%v) = %v, want %v", c.err, got, c.want)
+			}
+		})
+	}
+}
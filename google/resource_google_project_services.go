@@ -0,0 +1,282 @@
+package google
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"google.golang.org/api/servicemanagement/v1"
+)
+
+// servicesThatCannotBeDisabled is the set of services that the Service
+// Management API will refuse (or silently fail) to disable, usually because
+// another Google-managed resource in the project depends on them.
+var servicesThatCannotBeDisabled = map[string]struct{}{
+	"bigquery-json.googleapis.com": struct{}{},
+}
+
+// serviceDependencies lists meta-services that, when enabled, bring along a
+// set of underlying services that must be enabled first (or at the same
+// time). Google enables these transitively, but the Service Management API
+// reports them as independent entries in Services.List, so we need to know
+// about the relationship to compute a correct, dependency-ordered diff.
+var serviceDependencies = map[string][]string{
+	"cloudapis.googleapis.com": []string{
+		"bigquery-json.googleapis.com",
+		"container.googleapis.com",
+		"compute.googleapis.com",
+		"logging.googleapis.com",
+		"sqladmin.googleapis.com",
+		"storage-api.googleapis.com",
+		"storage-component.googleapis.com",
+	},
+}
+
+func resourceGoogleProjectServices() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceGoogleProjectServicesCreate,
+		Read:   resourceGoogleProjectServicesRead,
+		Update: resourceGoogleProjectServicesUpdate,
+		Delete: resourceGoogleProjectServicesDelete,
+
+		Schema: map[string]*schema.Schema{
+			"project": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"services": &schema.Schema{
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+		},
+	}
+}
+
+func resourceGoogleProjectServicesCreate(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	pid := d.Get("project").(string)
+
+	services := expandServices(d.Get("services").(*schema.Set))
+	if err := enableServices(services, pid, config); err != nil {
+		return fmt.Errorf("Error enabling services for project %q: %s", pid, err)
+	}
+
+	d.SetId(pid)
+	return resourceGoogleProjectServicesRead(d, meta)
+}
+
+func resourceGoogleProjectServicesRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	pid := d.Id()
+
+	enabled, err := listEnabledServices(pid, config)
+	if err != nil {
+		return fmt.Errorf("Error listing enabled services for project %q: %s", pid, err)
+	}
+	enabledSet := map[string]struct{}{}
+	for _, service := range enabled {
+		enabledSet[service] = struct{}{}
+	}
+
+	// Only report drift within the set of services this resource itself
+	// manages, not every API enabled on the project - that list can include
+	// GCP defaults or services enabled by other resources/tooling. Writing
+	// the raw full list to state would make the next update's diff try to
+	// disable all of those too.
+	var managedAndEnabled []string
+	for _, service := range expandServices(d.Get("services").(*schema.Set)) {
+		if _, ok := enabledSet[service]; ok {
+			managedAndEnabled = append(managedAndEnabled, service)
+		}
+	}
+
+	d.Set("project", pid)
+	d.Set("services", managedAndEnabled)
+	return nil
+}
+
+func resourceGoogleProjectServicesUpdate(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	pid := d.Id()
+
+	old, new := d.GetChange("services")
+	oldSet := old.(*schema.Set)
+	newSet := new.(*schema.Set)
+
+	toEnable := expandServices(newSet.Difference(oldSet))
+	toDisable := expandServices(oldSet.Difference(newSet))
+
+	if err := enableServices(toEnable, pid, config); err != nil {
+		return fmt.Errorf("Error enabling services for project %q: %s", pid, err)
+	}
+	if err := disableServices(toDisable, pid, config); err != nil {
+		return fmt.Errorf("Error disabling services for project %q: %s", pid, err)
+	}
+
+	return resourceGoogleProjectServicesRead(d, meta)
+}
+
+func resourceGoogleProjectServicesDelete(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	pid := d.Id()
+
+	services := expandServices(d.Get("services").(*schema.Set))
+	if err := disableServices(services, pid, config); err != nil {
+		return fmt.Errorf("Error disabling services for project %q: %s", pid, err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func expandServices(s *schema.Set) []string {
+	services := make([]string, s.Len())
+	for i, v := range s.List() {
+		services[i] = v.(string)
+	}
+	return services
+}
+
+// listEnabledServices returns the list of services currently enabled on the
+// project, as reported by the Service Management API.
+func listEnabledServices(pid string, config *Config) ([]string, error) {
+	var enabled []string
+	consumerId := "project:" + pid
+
+	call := config.clientServiceMan.Services.List().ConsumerId(consumerId)
+	for {
+		res, err := call.Do()
+		if err != nil {
+			return nil, err
+		}
+		for _, service := range res.Services {
+			enabled = append(enabled, service.ServiceName)
+		}
+		if res.NextPageToken == "" {
+			break
+		}
+		call = call.PageToken(res.NextPageToken)
+	}
+
+	sort.Strings(enabled)
+	return enabled, nil
+}
+
+// enableServices enables the given list of services on pid, expanding any
+// meta-services into their dependencies first so that the underlying
+// services are active before the meta-service that depends on them.
+func enableServices(services []string, pid string, config *Config) error {
+	for _, service := range orderServicesForEnable(services, serviceDependencies) {
+		if err := enableService(service, pid, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// disableServices disables the given list of services on pid, skipping any
+// service that is known not to support being disabled.
+func disableServices(services []string, pid string, config *Config) error {
+	for _, service := range services {
+		if _, cannotDisable := servicesThatCannotBeDisabled[service]; cannotDisable {
+			log.Printf("[WARN] Service %q cannot be disabled, leaving it enabled on project %q", service, pid)
+			continue
+		}
+		if err := disableService(service, pid, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orderServicesForEnable expands meta-services in services into their
+// dependencies (looked up in deps) and returns a single ordered,
+// de-duplicated list with dependencies appearing before the services that
+// depend on them. deps is passed in rather than read from the package-level
+// serviceDependencies var so the ordering logic can be exercised against an
+// arbitrary (including cyclic) dependency graph in tests.
+func orderServicesForEnable(services []string, deps map[string][]string) []string {
+	var ordered []string
+	done := map[string]struct{}{}
+	visiting := map[string]struct{}{}
+
+	var add func(service string)
+	add = func(service string) {
+		if _, ok := done[service]; ok {
+			return
+		}
+		if _, ok := visiting[service]; ok {
+			// A cycle in deps - log and break the cycle here rather than
+			// recursing forever.
+			log.Printf("[WARN] Cycle detected in service dependencies involving %q, skipping it for ordering purposes", service)
+			return
+		}
+
+		visiting[service] = struct{}{}
+		for _, dep := range deps[service] {
+			add(dep)
+		}
+		delete(visiting, service)
+
+		done[service] = struct{}{}
+		ordered = append(ordered, service)
+	}
+
+	for _, service := range services {
+		add(service)
+	}
+	return ordered
+}
+
+// enableService enables a single service on a project and waits for the
+// enable operation to finish.
+func enableService(service, pid string, config *Config) error {
+	name := fmt.Sprintf("%s/services/%s", prefixedProject(pid), service)
+	op, err := config.clientServiceMan.Services.Enable(name, &servicemanagement.EnableServiceRequest{
+		ConsumerId: "project:" + pid,
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("Error enabling service %q for project %q: %s", service, pid, err)
+	}
+
+	return serviceManagementOperationWait(config, op, fmt.Sprintf("service %q to enable", service))
+}
+
+// disableService disables a single service on a project and waits for the
+// disable operation to finish.
+func disableService(service, pid string, config *Config) error {
+	name := fmt.Sprintf("%s/services/%s", prefixedProject(pid), service)
+	op, err := config.clientServiceMan.Services.Disable(name, &servicemanagement.DisableServiceRequest{
+		ConsumerId: "project:" + pid,
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("Error disabling service %q for project %q: %s", service, pid, err)
+	}
+
+	return serviceManagementOperationWait(config, op, fmt.Sprintf("service %q to disable", service))
+}
+
+// serviceManagementOperationWait polls a long-running Service Management
+// operation until it completes, mirroring resourceManagerOperationWait for
+// the servicemanagement API's own Operation type.
+func serviceManagementOperationWait(config *Config, op *servicemanagement.Operation, activity string) error {
+	for !op.Done {
+		time.Sleep(2 * time.Second)
+
+		var err error
+		op, err = config.clientServiceMan.Operations.Get(op.Name).Do()
+		if err != nil {
+			return fmt.Errorf("Error waiting for %s: %s", activity, err)
+		}
+
+		if op.Error != nil {
+			return fmt.Errorf("Error waiting for %s: %s", activity, op.Error.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package google
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestExpandServices(t *testing.T) {
+	s := schema.NewSet(schema.HashString, []interface{}{"b.googleapis.com", "a.googleapis.com"})
+	got := expandServices(s)
+	want := []string{"a.googleapis.com", "b.googleapis.com"}
+	if !sameStringSet(got, want) {
+		t.Errorf("expandServices(%v) = %v, want %v", s.List(), got, want)
+	}
+}
+
+func TestOrderServicesForEnable_ExpandsDependencies(t *testing.T) {
+	ordered := orderServicesForEnable([]string{"cloudapis.googleapis.com"}, serviceDependencies)
+
+	index := map[string]int{}
+	for i, service := range ordered {
+		index[service] = i
+	}
+
+	if _, ok := index["cloudapis.googleapis.com"]; !ok {
+		t.Fatalf("expected cloudapis.googleapis.com in %v", ordered)
+	}
+	for _, dep := range serviceDependencies["cloudapis.googleapis.com"] {
+		if index[dep] >= index["cloudapis.googleapis.com"] {
+			t.Errorf("expected dependency %q to be ordered before cloudapis.googleapis.com, got order %v", dep, ordered)
+		}
+	}
+}
+
+func TestOrderServicesForEnable_Dedupes(t *testing.T) {
+	ordered := orderServicesForEnable([]string{
+		"compute.googleapis.com",
+		"cloudapis.googleapis.com",
+		"compute.googleapis.com",
+	}, serviceDependencies)
+
+	seen := map[string]int{}
+	for _, service := range ordered {
+		seen[service]++
+	}
+	for service, count := range seen {
+		if count != 1 {
+			t.Errorf("service %q appeared %d times in %v, want 1", service, count, ordered)
+		}
+	}
+}
+
+// TestOrderServicesForEnable_BreaksCycles guards against a regression to the
+// unbounded recursion that a cyclic dependency entry used to cause. It
+// passes a cyclic map directly as the deps argument, rather than mutating
+// the package-level serviceDependencies var, so the call is a plain
+// synchronous, race-free invocation like any other table case.
+func TestOrderServicesForEnable_BreaksCycles(t *testing.T) {
+	cyclic := map[string][]string{
+		"a.googleapis.com": []string{"b.googleapis.com"},
+		"b.googleapis.com": []string{"a.googleapis.com"},
+	}
+
+	ordered := orderServicesForEnable([]string{"a.googleapis.com"}, cyclic)
+
+	if len(ordered) != 2 || !sameStringSet(ordered, []string{"a.googleapis.com", "b.googleapis.com"}) {
+		t.Errorf("orderServicesForEnable() = %v, want both services present exactly once", ordered)
+	}
+}
+
+func sameStringSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	am := map[string]int{}
+	for _, s := range a {
+		am[s]++
+	}
+	bm := map[string]int{}
+	for _, s := range b {
+		bm[s]++
+	}
+	return reflect.DeepEqual(am, bm)
+}
@@ -0,0 +1,58 @@
+package google
+
+import (
+	"fmt"
+
+	"google.golang.org/api/compute/v1"
+)
+
+// setProjectXpnHost enables pid as a Shared VPC (XPN) host project.
+func setProjectXpnHost(pid string, config *Config) error {
+	op, err := config.clientCompute.Projects.EnableXpnHost(pid).Do()
+	if err != nil {
+		return err
+	}
+	return computeOperationWaitGlobal(config, op, pid, "Enabling Shared VPC host")
+}
+
+// clearProjectXpnHost disables pid as a Shared VPC host project.
+func clearProjectXpnHost(pid string, config *Config) error {
+	op, err := config.clientCompute.Projects.DisableXpnHost(pid).Do()
+	if err != nil {
+		return err
+	}
+	return computeOperationWaitGlobal(config, op, pid, "Disabling Shared VPC host")
+}
+
+// setProjectXpnResource attaches serviceProject to hostProject as a Shared
+// VPC service project.
+func setProjectXpnResource(hostProject, serviceProject string, config *Config) error {
+	req := &compute.ProjectsEnableXpnResourceRequest{
+		XpnResource: &compute.XpnResourceId{
+			Id:   serviceProject,
+			Type: "PROJECT",
+		},
+	}
+
+	op, err := config.clientCompute.Projects.EnableXpnResource(hostProject, req).Do()
+	if err != nil {
+		return err
+	}
+	return computeOperationWaitGlobal(config, op, hostProject, fmt.Sprintf("Attaching service project %s to Shared VPC host", serviceProject))
+}
+
+// clearProjectXpnResource detaches serviceProject from hostProject.
+func clearProjectXpnResource(hostProject, serviceProject string, config *Config) error {
+	req := &compute.ProjectsDisableXpnResourceRequest{
+		XpnResource: &compute.XpnResourceId{
+			Id:   serviceProject,
+			Type: "PROJECT",
+		},
+	}
+
+	op, err := config.clientCompute.Projects.DisableXpnResource(hostProject, req).Do()
+	if err != nil {
+		return err
+	}
+	return computeOperationWaitGlobal(config, op, hostProject, fmt.Sprintf("Detaching service project %s from Shared VPC host", serviceProject))
+}
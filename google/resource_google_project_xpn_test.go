@@ -0,0 +1,44 @@
+package google
+
+import (
+	"net/http"
+	"testing"
+
+	"google.golang.org/api/googleapi"
+)
+
+type errString string
+
+func (e errString) Error() string { return string(e) }
+
+func TestIsComputeApiDisabledError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "compute api not enabled",
+			err:  &googleapi.Error{Code: http.StatusForbidden, Message: "Compute Engine API has not been used in project 12345 before or it is disabled"},
+			want: true,
+		},
+		{
+			name: "unrelated 403",
+			err:  &googleapi.Error{Code: http.StatusForbidden, Message: "The caller does not have permission"},
+			want: false,
+		},
+		{
+			name: "non-googleapi error",
+			err:  errString("boom"),
+			want: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isComputeApiDisabledError(c.err); got != c.want {
+				t.Errorf("isComputeApiDisabledError(%v) = %v, want %v", c.err, got, c.want)
+			}
+		})
+	}
+}